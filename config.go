@@ -0,0 +1,21 @@
+package main
+
+import (
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// NewClientConfig builds a *rest.Config for talking to a Kubernetes cluster.
+// It first tries the in-cluster config so o-a-pod can run as a Deployment
+// inside the cluster it manages, and falls back to kubeconfigPath (plus
+// overrides) for running from a workstation.
+func NewClientConfig(kubeconfigPath string, overrides *clientcmd.ConfigOverrides) (*rest.Config, error) {
+	if cfg, err := rest.InClusterConfig(); err == nil {
+		return cfg, nil
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfigPath
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
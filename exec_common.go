@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+)
+
+// writeTempInventory writes inventory to a temporary file and returns its
+// path, for Runners that exec ansible-playbook directly rather than
+// mounting a ConfigMap.
+func writeTempInventory(inventory string) (string, error) {
+	f, err := ioutil.TempFile("", "o-a-pod-inventory-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(inventory); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// extraVarsArgs renders vars as ansible-playbook --extra-vars flags.
+func extraVarsArgs(vars map[string]string) []string {
+	args := make([]string, 0, len(vars)*2)
+	for k, v := range vars {
+		args = append(args, "--extra-vars", fmt.Sprintf("%s=%s", k, v))
+	}
+	return args
+}
+
+// runAnsiblePlaybookLocally execs binary with args, streaming stdout to out.
+// It returns a succeeded Result if the command exits cleanly, and an error
+// carrying the command's stderr otherwise. Shared by
+// LocalRunner and SSHHostListRunner, which both run ansible-playbook as a
+// subprocess rather than inside a Kubernetes Job.
+func runAnsiblePlaybookLocally(binary string, args []string, out io.Writer) (Result, error) {
+	if binary == "" {
+		binary = "ansible-playbook"
+	}
+	if out == nil {
+		out = ioutil.Discard
+	}
+
+	cmd := exec.Command(binary, args...)
+	var stderr bytes.Buffer
+	cmd.Stdout = out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return Result{}, fmt.Errorf("%s failed: %s: %s", binary, err.Error(), stderr.String())
+	}
+	return Result{Succeeded: true, Message: "playbook completed successfully"}, nil
+}
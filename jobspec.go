@@ -0,0 +1,137 @@
+package main
+
+import (
+	"path/filepath"
+
+	kbatch "k8s.io/api/batch/v1"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ansibleJobOptions describes everything needed to build the Job that runs
+// a single playbook, shared by the legacy single-playbook CLI path and the
+// PlaybookRun controller.
+type ansibleJobOptions struct {
+	Name               string
+	Namespace          string
+	Image              string
+	Playbook           string
+	InventoryConfigMap string
+	SSHSecret          string
+	ServiceAccountName string
+	ActiveDeadlineSecs int64
+	ExtraEnv           []kapi.EnvVar
+	ExtraVars          map[string]string
+	OwnerReferences    []metav1.OwnerReference
+}
+
+// buildAnsibleJob assembles the Job+Pod spec that runs ansible-playbook
+// against the given inventory ConfigMap and SSH secret. It's the single
+// place that knows about the openshift/origin-ansible image's conventions
+// (env vars, volume mount paths, the privatekey.pem file name).
+func buildAnsibleJob(opts ansibleJobOptions) *kbatch.Job {
+	env := append([]kapi.EnvVar{
+		{
+			Name:  "INVENTORY_FILE",
+			Value: "/ansible/inventory/hosts",
+		},
+		{
+			Name:  "PLAYBOOK_FILE",
+			Value: opts.Playbook,
+		},
+		{
+			Name:  "ANSIBLE_HOST_KEY_CHECKING",
+			Value: "False",
+		},
+		{
+			Name:  "OPTS",
+			Value: "-vvv --private-key=/ansible/ssh/privatekey.pem",
+		},
+	}, opts.ExtraEnv...)
+
+	// The image's playbooks live under /usr/share/ansible/openshift-ansible/,
+	// with opts.Playbook (e.g. "playbooks/byo/config.yml") relative to that.
+	playbookPath := filepath.Join("/usr/share/ansible/openshift-ansible", opts.Playbook)
+
+	command := []string{"ansible-playbook", "-i", "/ansible/inventory/hosts", "--private-key", "/ansible/ssh/privatekey.pem"}
+	command = append(command, extraVarsArgs(opts.ExtraVars)...)
+	command = append(command, playbookPath)
+
+	runAsUser := int64(0)
+	sshKeyFileMode := int32(0600)
+	podSpec := kapi.PodSpec{
+		DNSPolicy:          kapi.DNSClusterFirst,
+		RestartPolicy:      kapi.RestartPolicyNever,
+		ServiceAccountName: opts.ServiceAccountName,
+		HostNetwork:        true,
+
+		Containers: []kapi.Container{
+			{
+				Name:  opts.Name,
+				Image: opts.Image,
+				Env:   env,
+				SecurityContext: &kapi.SecurityContext{
+					RunAsUser: &runAsUser,
+				},
+				VolumeMounts: []kapi.VolumeMount{
+					{
+						Name:      "inventory",
+						MountPath: "/ansible/inventory/",
+					},
+					{
+						Name:      "sshkey",
+						MountPath: "/ansible/ssh/",
+					},
+				},
+
+				// TODO: drop this once https://github.com/openshift/openshift-ansible/pull/6320 merges, the default run script should then work:
+				Command: command,
+			},
+		},
+		Volumes: []kapi.Volume{
+			{
+				Name: "inventory",
+				VolumeSource: kapi.VolumeSource{
+					ConfigMap: &kapi.ConfigMapVolumeSource{
+						LocalObjectReference: kapi.LocalObjectReference{
+							Name: opts.InventoryConfigMap,
+						},
+					},
+				},
+			},
+			{
+				Name: "sshkey",
+				VolumeSource: kapi.VolumeSource{
+					Secret: &kapi.SecretVolumeSource{
+						SecretName: opts.SSHSecret,
+						Items: []kapi.KeyToPath{
+							{
+								Key:  "ssh-privatekey",
+								Path: "privatekey.pem",
+								Mode: &sshKeyFileMode,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	completions := int32(1)
+	deadline := opts.ActiveDeadlineSecs
+
+	return &kbatch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            opts.Name,
+			Namespace:       opts.Namespace,
+			OwnerReferences: opts.OwnerReferences,
+		},
+		Spec: kbatch.JobSpec{
+			Completions:           &completions,
+			ActiveDeadlineSeconds: &deadline,
+			Template: kapi.PodTemplateSpec{
+				Spec: podSpec,
+			},
+		},
+	}
+}
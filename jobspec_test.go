@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildAnsibleJobRunsTheRequestedPlaybook(t *testing.T) {
+	cases := []struct {
+		name     string
+		playbook string
+		want     string
+	}{
+		{
+			name:     "byo config",
+			playbook: "playbooks/byo/config.yml",
+			want:     "/usr/share/ansible/openshift-ansible/playbooks/byo/config.yml",
+		},
+		{
+			name:     "a different playbook entirely",
+			playbook: "playbooks/openshift-glusterfs/config.yml",
+			want:     "/usr/share/ansible/openshift-ansible/playbooks/openshift-glusterfs/config.yml",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			job := buildAnsibleJob(ansibleJobOptions{Name: "test-job", Playbook: tc.playbook})
+			command := job.Spec.Template.Spec.Containers[0].Command
+			got := command[len(command)-1]
+			if got != tc.want {
+				t.Errorf("playbook path = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildAnsibleJobThreadsExtraVars(t *testing.T) {
+	job := buildAnsibleJob(ansibleJobOptions{
+		Name:     "test-job",
+		Playbook: "playbooks/byo/config.yml",
+		ExtraVars: map[string]string{
+			"openshift_deployment_type": "origin",
+		},
+	})
+
+	command := strings.Join(job.Spec.Template.Spec.Containers[0].Command, " ")
+	if !strings.Contains(command, "--extra-vars openshift_deployment_type=origin") {
+		t.Errorf("command %q does not pass through ExtraVars", command)
+	}
+}
+
+func TestBuildAnsibleJobMountsInventoryAndSSHKey(t *testing.T) {
+	job := buildAnsibleJob(ansibleJobOptions{
+		Name:               "test-job",
+		Playbook:           "playbooks/byo/config.yml",
+		InventoryConfigMap: "test-inventory",
+		SSHSecret:          "test-ssh-key",
+	})
+
+	volumes := job.Spec.Template.Spec.Volumes
+	if len(volumes) != 2 {
+		t.Fatalf("got %d volumes, want 2", len(volumes))
+	}
+	if volumes[0].ConfigMap.Name != "test-inventory" {
+		t.Errorf("inventory volume references %q, want %q", volumes[0].ConfigMap.Name, "test-inventory")
+	}
+	if volumes[1].Secret.SecretName != "test-ssh-key" {
+		t.Errorf("ssh volume references %q, want %q", volumes[1].Secret.SecretName, "test-ssh-key")
+	}
+}
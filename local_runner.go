@@ -0,0 +1,31 @@
+package main
+
+import "os"
+
+// LocalRunner execs ansible-playbook directly on the machine o-a-pod is
+// running on, against a temporary inventory file. Useful when there's no
+// Kubernetes cluster to run a KubeJobRunner Job against, e.g. in-place
+// upgrades run from a bastion host.
+type LocalRunner struct {
+	// Binary is the ansible-playbook executable to run; defaults to
+	// "ansible-playbook" on PATH.
+	Binary string
+}
+
+func newLocalRunner() *LocalRunner {
+	return &LocalRunner{Binary: "ansible-playbook"}
+}
+
+// RunPlaybook satisfies the Runner interface.
+func (r *LocalRunner) RunPlaybook(inventory string, playbook string, opts RunOptions) (Result, error) {
+	inventoryFile, err := writeTempInventory(inventory)
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.Remove(inventoryFile)
+
+	args := append([]string{"-i", inventoryFile}, extraVarsArgs(opts.ExtraVars)...)
+	args = append(args, playbook)
+
+	return runAnsiblePlaybookLocally(r.Binary, args, opts.Log)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -11,34 +12,63 @@ import (
 	kapi "k8s.io/api/core/v1"
 	kapierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apirand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/retry"
+
+	// Registers the OIDC/GCP/Azure auth providers used by ConfigOverrides
+	// when running against clusters that require them.
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
 )
 
 const (
 	openshiftAnsibleImage          = "openshift/origin-ansible:v3.7"
 	openshiftAnsibleServiceAccount = "openshift-ansible"
-	inventoryConfigMap             = "ansible-inventory"
-	sshPrivateKeySecret            = "ssh-private-key"
 )
 
-type ansibleRunner struct {
+type KubeJobRunner struct {
 	KubeClient kubernetes.Interface
 	Namespace  string
 	Image      string
+
+	// RESTConfig is used to build clients for APIs kubernetes.Interface
+	// doesn't cover, such as the OpenShift security.openshift.io client
+	// EnsurePrerequisites needs for SecurityContextConstraints.
+	RESTConfig *rest.Config
+
+	// JobName is populated by RunPlaybook and consumed by WaitForCompletion.
+	JobName string
+
+	// LogStreamBackoff and WatchBackoff govern WaitForCompletion's retries
+	// against the pod log endpoint and the Job watch, respectively. Both
+	// default to sensible values in newKubeJobRunner; override them for
+	// tests or to tune reconnect behavior against a flaky apiserver.
+	LogStreamBackoff wait.Backoff
+	WatchBackoff     wait.Backoff
 }
 
-func newAnsibleRunner(kubeClient kubernetes.Interface, namespace string) *ansibleRunner {
-	return &ansibleRunner{
-		KubeClient: kubeClient,
-		Namespace:  namespace,
-		Image:      openshiftAnsibleImage,
+func newKubeJobRunner(kubeClient kubernetes.Interface, restConfig *rest.Config, namespace string) *KubeJobRunner {
+	return &KubeJobRunner{
+		KubeClient:       kubeClient,
+		RESTConfig:       restConfig,
+		Namespace:        namespace,
+		Image:            openshiftAnsibleImage,
+		LogStreamBackoff: defaultLogStreamBackoff,
+		WatchBackoff:     defaultWatchBackoff,
 	}
 }
-func (r *ansibleRunner) createInventoryConfigMap(inventory string) error {
+
+// createConfigMap creates or updates the named inventory ConfigMap, owned
+// by owner so it's garbage-collected alongside whatever created it.
+func (r *KubeJobRunner) createConfigMap(name string, inventory string, owner []metav1.OwnerReference) error {
 	cfgmap := &kapi.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: inventoryConfigMap,
+			Name:            name,
+			OwnerReferences: owner,
 		},
 		Data: map[string]string{
 			"hosts": inventory,
@@ -46,138 +76,164 @@ func (r *ansibleRunner) createInventoryConfigMap(inventory string) error {
 	}
 	_, err := r.KubeClient.CoreV1().ConfigMaps(r.Namespace).Create(cfgmap)
 	if err != nil && kapierrors.IsAlreadyExists(err) {
-		// Update existing configmap if it already exists:
-		fmt.Println("ansible-hosts configmap already exists, attempting update...")
+		fmt.Printf("%s configmap already exists, attempting update...\n", name)
 		_, err = r.KubeClient.CoreV1().ConfigMaps(r.Namespace).Update(cfgmap)
 		if err != nil {
-			fmt.Printf("error updating ansible-hosts configmap: %s\n", err.Error())
+			fmt.Printf("error updating %s configmap: %s\n", name, err.Error())
 			return err
 		}
 	} else if err != nil {
-		fmt.Printf("error creating ansible-hosts configmap: %s\n", err.Error())
+		fmt.Printf("error creating %s configmap: %s\n", name, err.Error())
+		return err
 	} else {
-		fmt.Printf("ansible-hosts configmap created successfully\n")
+		fmt.Printf("%s configmap created successfully\n", name)
 	}
 
-	return err
+	return nil
 }
 
-func (r *ansibleRunner) RunPlaybook(inventory string, playbook string) error {
-
-	err := r.createInventoryConfigMap(inventory)
-	if err != nil {
-		return err
-	}
-
-	jobName := "openshift-ansible-test-job"
-	env := []kapi.EnvVar{
-		{
-			Name:  "INVENTORY_FILE",
-			Value: "/ansible/inventory/hosts",
-		},
-		{
-			Name:  "PLAYBOOK_FILE",
-			Value: playbook,
-		},
-		{
-			Name:  "ANSIBLE_HOST_KEY_CHECKING",
-			Value: "False",
+// createSSHKeySecret creates or updates the named Secret holding the SSH
+// private key the ansible Job uses to reach its targets, owned by owner so
+// it's garbage-collected alongside whatever created it.
+func (r *KubeJobRunner) createSSHKeySecret(name string, privateKey []byte, owner []metav1.OwnerReference) error {
+	secret := &kapi.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			OwnerReferences: owner,
 		},
-		{
-			Name:  "OPTS",
-			Value: "-vvv --private-key=/ansible/ssh/privatekey.pem",
+		Type: kapi.SecretTypeSSHAuth,
+		Data: map[string][]byte{
+			"ssh-privatekey": privateKey,
 		},
 	}
-	runAsUser := int64(0)
-	sshKeyFileMode := int32(0600)
-	podSpec := kapi.PodSpec{
-		DNSPolicy:          kapi.DNSClusterFirst,
-		RestartPolicy:      kapi.RestartPolicyNever,
-		ServiceAccountName: openshiftAnsibleServiceAccount,
-		HostNetwork:        true,
-
-		Containers: []kapi.Container{
-			{
-				Name:  jobName,
-				Image: r.Image,
-				Env:   env,
-				SecurityContext: &kapi.SecurityContext{
-					RunAsUser: &runAsUser,
-				},
-				VolumeMounts: []kapi.VolumeMount{
-					{
-						Name:      "inventory",
-						MountPath: "/ansible/inventory/",
-					},
-					{
-						Name:      "sshkey",
-						MountPath: "/ansible/ssh/",
-					},
-				},
-				//Command: []string{"sleep", "1000000"},
-
-				// TODO: drop this once https://github.com/openshift/openshift-ansible/pull/6320 merges, the default run script should then work:
-				Command: []string{"ansible-playbook", "-i", "/ansible/inventory/hosts", "--private-key", "/ansible/ssh/privatekey.pem", "/usr/share/ansible/openshift-ansible/playbooks/byo/config.yml"},
-			},
-		},
-		Volumes: []kapi.Volume{
-			{
-				Name: "inventory",
-				VolumeSource: kapi.VolumeSource{
-					ConfigMap: &kapi.ConfigMapVolumeSource{
-						LocalObjectReference: kapi.LocalObjectReference{
-							Name: inventoryConfigMap,
-						},
-					},
-				},
-			},
-			{
-				Name: "sshkey",
-				VolumeSource: kapi.VolumeSource{
-					Secret: &kapi.SecretVolumeSource{
-						SecretName: sshPrivateKeySecret,
-						Items: []kapi.KeyToPath{
-							{
-								Key:  "ssh-privatekey",
-								Path: "privatekey.pem",
-								Mode: &sshKeyFileMode,
-							},
-						},
-					},
-				},
-			},
-		},
+	_, err := r.KubeClient.CoreV1().Secrets(r.Namespace).Create(secret)
+	if err != nil && kapierrors.IsAlreadyExists(err) {
+		fmt.Printf("%s secret already exists, attempting update...\n", name)
+		_, err = r.KubeClient.CoreV1().Secrets(r.Namespace).Update(secret)
+		if err != nil {
+			fmt.Printf("error updating %s secret: %s\n", name, err.Error())
+		}
+	} else if err != nil {
+		fmt.Printf("error creating %s secret: %s\n", name, err.Error())
 	}
+	return err
+}
 
-	completions := int32(1)
-	deadline := int64(60 * 60) // one hour for now
+// cleanupArtifacts best-effort deletes the per-run inventory ConfigMap and
+// SSH key Secret RunPlaybook created, once the Job that used them has
+// completed.
+func (r *KubeJobRunner) cleanupArtifacts(configMapName, secretName string) {
+	if err := r.KubeClient.CoreV1().ConfigMaps(r.Namespace).Delete(configMapName, &metav1.DeleteOptions{}); err != nil && !kapierrors.IsNotFound(err) {
+		fmt.Printf("error cleaning up configmap %s: %s\n", configMapName, err.Error())
+	}
+	if err := r.KubeClient.CoreV1().Secrets(r.Namespace).Delete(secretName, &metav1.DeleteOptions{}); err != nil && !kapierrors.IsNotFound(err) {
+		fmt.Printf("error cleaning up secret %s: %s\n", secretName, err.Error())
+	}
+}
 
-	meta := metav1.ObjectMeta{
-		Name:      jobName,
-		Namespace: r.Namespace,
+// loadSSHPrivateKey returns the SSH private key material RunPlaybook should
+// use, from whichever of opts.SSHPrivateKey/SSHPrivateKeyPath was set.
+func loadSSHPrivateKey(opts RunOptions) ([]byte, error) {
+	if len(opts.SSHPrivateKey) > 0 {
+		return opts.SSHPrivateKey, nil
+	}
+	if opts.SSHPrivateKeyPath != "" {
+		return ioutil.ReadFile(opts.SSHPrivateKeyPath)
 	}
+	return nil, fmt.Errorf("no SSH private key provided: set RunOptions.SSHPrivateKey or SSHPrivateKeyPath")
+}
 
-	job := &kbatch.Job{
-		ObjectMeta: meta,
-		Spec: kbatch.JobSpec{
-			Completions:           &completions,
-			ActiveDeadlineSeconds: &deadline,
-			Template: kapi.PodTemplateSpec{
-				Spec: podSpec,
-			},
-		},
+// jobOwnerReference builds the OwnerReference used to make a Job's
+// generated ConfigMap/Secret subject to Kubernetes garbage collection when
+// the Job itself is deleted.
+func jobOwnerReference(job *kbatch.Job) metav1.OwnerReference {
+	blockOwnerDeletion := true
+	controller := true
+	return metav1.OwnerReference{
+		APIVersion:         "batch/v1",
+		Kind:               "Job",
+		Name:               job.Name,
+		UID:                job.UID,
+		BlockOwnerDeletion: &blockOwnerDeletion,
+		Controller:         &controller,
+	}
+}
+
+// RunPlaybook submits a uniquely-named Job that runs playbook against
+// inventory and blocks until it completes, satisfying the Runner
+// interface. The inventory ConfigMap and SSH key Secret it creates are
+// named after the run so concurrent invocations don't clobber each other,
+// and are cleaned up once the Job completes unless opts.KeepArtifacts is
+// set.
+func (r *KubeJobRunner) RunPlaybook(inventory string, playbook string, opts RunOptions) (Result, error) {
+	sshKey, err := loadSSHPrivateKey(opts)
+	if err != nil {
+		return Result{}, err
 	}
 
+	runID := apirand.String(8)
+	jobName := fmt.Sprintf("openshift-ansible-test-job-%s", runID)
+	inventoryCMName := fmt.Sprintf("ansible-inventory-%s", runID)
+	sshSecretName := fmt.Sprintf("ssh-key-%s", runID)
+	r.JobName = jobName
+
+	job := buildAnsibleJob(ansibleJobOptions{
+		Name:               jobName,
+		Namespace:          r.Namespace,
+		Image:              r.Image,
+		Playbook:           playbook,
+		InventoryConfigMap: inventoryCMName,
+		SSHSecret:          sshSecretName,
+		ServiceAccountName: openshiftAnsibleServiceAccount,
+		ActiveDeadlineSecs: 60 * 60, // one hour for now
+		ExtraVars:          opts.ExtraVars,
+	})
+
 	// Create the job client
 	jobClient := r.KubeClient.Batch().Jobs(r.Namespace)
 
 	// Submit the job
-	_, err = jobClient.Create(job)
+	created, err := jobClient.Create(job)
 	if err != nil && kapierrors.IsAlreadyExists(err) {
 		fmt.Println("job already exists, attempting update...")
-		_, err = jobClient.Update(job)
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			existing, getErr := jobClient.Get(jobName, metav1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			job.ResourceVersion = existing.ResourceVersion
+			updated, updateErr := jobClient.Update(job)
+			created = updated
+			return updateErr
+		})
 	}
-	return err
+	if err != nil {
+		return Result{}, err
+	}
+
+	owner := []metav1.OwnerReference{jobOwnerReference(created)}
+
+	if err := r.createConfigMap(inventoryCMName, inventory, owner); err != nil {
+		return Result{}, err
+	}
+	if err := r.createSSHKeySecret(sshSecretName, sshKey, owner); err != nil {
+		return Result{}, err
+	}
+
+	out := opts.Log
+	if out == nil {
+		out = ioutil.Discard
+	}
+	playbookResult, err := r.WaitForCompletion(context.Background(), out)
+
+	if !opts.KeepArtifacts {
+		r.cleanupArtifacts(inventoryCMName, sshSecretName)
+	}
+
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Succeeded: playbookResult.Succeeded, Message: playbookResult.Message}, nil
 }
 
 func main() {
@@ -187,36 +243,130 @@ func main() {
 	} else {
 		kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
 	}
+	kubeContext := flag.String("context", "", "(optional) kubeconfig context to use")
+	server := flag.String("server", "", "(optional) address of the API server, overriding the kubeconfig")
+	token := flag.String("token", "", "(optional) bearer token to use for authentication")
+	insecureSkipTLSVerify := flag.Bool("insecure-skip-tls-verify", false, "(optional) skip verification of the API server's certificate")
+	runnerType := flag.String("runner", "kube", "which Runner to execute the playbook with: kube, ssh, or local; or \"controller\" to run the PlaybookRun controller instead of a single playbook")
+	sshHosts := flag.String("ssh-hosts", "", "(runner=ssh) comma-separated list of user@host:port targets")
+	sshKey := flag.String("ssh-key", "", "path to the SSH private key used to reach the playbook's targets")
+	keepArtifacts := flag.Bool("keep-artifacts", false, "(runner=kube) retain the per-run inventory ConfigMap and SSH key Secret after the job completes")
 	flag.Parse()
 
-	if len(os.Args) != 2 {
+	if *runnerType == "controller" {
+		runPlaybookRunController(*kubeconfig, *kubeContext, *server, *token, *insecureSkipTLSVerify)
+		return
+	}
+
+	if len(flag.Args()) > 1 {
+		panic("USAGE: ./o-a-pod [/path/to/ansible/inventory]")
+	}
+
+	var inventory string
+	switch {
+	case len(flag.Args()) == 1:
+		inventoryBytes, err := ioutil.ReadFile(flag.Args()[0])
+		if err != nil {
+			panic(err.Error())
+		}
+		inventory = string(inventoryBytes)
+	case *runnerType == "ssh" && *sshHosts != "":
+		// SSHHostListRunner renders its own inventory from --ssh-hosts,
+		// the bootstrap case where there's no existing inventory file
+		// (e.g. standing up a cluster before it exists to run a Job against).
+	default:
 		panic("USAGE: ./o-a-pod /path/to/ansible/inventory")
 	}
 
-	inventoryBytes, err := ioutil.ReadFile(os.Args[1])
+	var runner Runner
+	switch *runnerType {
+	case "kube":
+		config, err := buildClientConfig(*kubeconfig, *kubeContext, *server, *token, *insecureSkipTLSVerify)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		kubeRunner := newKubeJobRunner(clientset, config, "ansible-test")
+		if err := kubeRunner.EnsurePrerequisites(context.Background()); err != nil {
+			panic(err.Error())
+		}
+		runner = kubeRunner
+	case "ssh":
+		hosts, err := parseSSHHosts(*sshHosts)
+		if err != nil {
+			panic(err.Error())
+		}
+		runner = newSSHHostListRunner(hosts, *sshKey)
+	case "local":
+		runner = newLocalRunner()
+	default:
+		panic(fmt.Sprintf("unknown --runner %q, must be one of: kube, ssh, local", *runnerType))
+	}
+
+	result, err := runner.RunPlaybook(inventory, "playbooks/byo/config.yml", RunOptions{
+		Log:               os.Stdout,
+		SSHPrivateKeyPath: *sshKey,
+		KeepArtifacts:     *keepArtifacts,
+	})
 	if err != nil {
 		panic(err.Error())
 	}
-	inventory := string(inventoryBytes)
+	if !result.Succeeded {
+		panic(result.Message)
+	}
+}
+
+// buildClientConfig assembles the ConfigOverrides shared by the kube Runner
+// and the PlaybookRun controller from their common CLI flags, and resolves
+// them to a *rest.Config via NewClientConfig.
+func buildClientConfig(kubeconfigPath, contextName, server, token string, insecureSkipTLSVerify bool) (*rest.Config, error) {
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: contextName,
+		ClusterInfo: clientcmdapi.Cluster{
+			Server:                server,
+			InsecureSkipTLSVerify: insecureSkipTLSVerify,
+		},
+		AuthInfo: clientcmdapi.AuthInfo{
+			Token: token,
+		},
+	}
+	return NewClientConfig(kubeconfigPath, overrides)
+}
 
-	// use the current context in kubeconfig
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+// runPlaybookRunController registers the PlaybookRun CRD if needed and
+// blocks running the PlaybookRunController informer, reconciling every
+// PlaybookRun in the "ansible-test" namespace into a Job.
+func runPlaybookRunController(kubeconfigPath, contextName, server, token string, insecureSkipTLSVerify bool) {
+	config, err := buildClientConfig(kubeconfigPath, contextName, server, token, insecureSkipTLSVerify)
 	if err != nil {
 		panic(err.Error())
 	}
 
-	// create the clientset
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		panic(err.Error())
 	}
 
-	ar := newAnsibleRunner(clientset, "ansible-test")
-	err = ar.RunPlaybook(inventory, "playbooks/byo/config.yml")
+	if err := EnsurePlaybookRunCRD(config); err != nil {
+		panic(err.Error())
+	}
+
+	if err := newKubeJobRunner(clientset, config, "ansible-test").EnsurePrerequisites(context.Background()); err != nil {
+		panic(err.Error())
+	}
+
+	crdClient, err := newPlaybookRunRESTClient(config)
 	if err != nil {
 		panic(err.Error())
 	}
 
+	controller := NewPlaybookRunController(clientset, config, crdClient, "ansible-test")
+	controller.Run(make(chan struct{}))
 }
 
 func homeDir() string {
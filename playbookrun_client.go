@@ -0,0 +1,67 @@
+package main
+
+import (
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/rest"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+)
+
+// newPlaybookRunRESTClient builds a rest.Interface scoped to the PlaybookRun
+// CRD's group/version, suitable for use with cache.NewListWatchFromClient.
+// There's no generated clientset for PlaybookRun, so this talks to the
+// apiserver with the same low-level rest.RESTClientFor machinery the
+// generated clientsets use under the hood.
+func newPlaybookRunRESTClient(cfg *rest.Config) (rest.Interface, error) {
+	scheme := runtime.NewScheme()
+	if err := addPlaybookRunTypes(scheme); err != nil {
+		return nil, err
+	}
+
+	config := *cfg
+	config.GroupVersion = &PlaybookRunSchemeGroupVersion
+	config.APIPath = "/apis"
+	config.ContentType = runtime.ContentTypeJSON
+	config.NegotiatedSerializer = serializer.NewCodecFactory(scheme)
+
+	return rest.RESTClientFor(&config)
+}
+
+// EnsurePlaybookRunCRD registers the PlaybookRun CustomResourceDefinition
+// with the apiserver if it doesn't already exist.
+func EnsurePlaybookRunCRD(cfg *rest.Config) error {
+	client, err := apiextensionsclient.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	crd := &apiextensionsv1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: PlaybookRunResourcePlural + "." + PlaybookRunGroupName,
+		},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group:   PlaybookRunGroupName,
+			Version: PlaybookRunVersion,
+			Scope:   apiextensionsv1beta1.NamespaceScoped,
+			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+				Plural: PlaybookRunResourcePlural,
+				Kind:   PlaybookRunResourceKind,
+			},
+			// Required for updateStatus's .SubResource("status") PUT to be
+			// accepted by the apiserver instead of 404ing.
+			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+			},
+		},
+	}
+
+	_, err = client.ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd)
+	if err != nil && !kapierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
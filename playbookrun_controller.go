@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+)
+
+// PlaybookRunController watches PlaybookRun custom resources and, per
+// object, generates a uniquely-named ansible Job and keeps the
+// PlaybookRun's status in sync with that Job until it completes.
+type PlaybookRunController struct {
+	KubeClient kubernetes.Interface
+	RESTConfig *rest.Config
+	CRDClient  rest.Interface
+	Namespace  string
+}
+
+// NewPlaybookRunController builds a controller scoped to namespace. Passing
+// metav1.NamespaceAll watches PlaybookRuns across every namespace.
+func NewPlaybookRunController(kubeClient kubernetes.Interface, restConfig *rest.Config, crdClient rest.Interface, namespace string) *PlaybookRunController {
+	return &PlaybookRunController{
+		KubeClient: kubeClient,
+		RESTConfig: restConfig,
+		CRDClient:  crdClient,
+		Namespace:  namespace,
+	}
+}
+
+// Run starts the PlaybookRun informer and blocks until stopCh is closed.
+func (c *PlaybookRunController) Run(stopCh <-chan struct{}) {
+	source := cache.NewListWatchFromClient(c.CRDClient, PlaybookRunResourcePlural, c.Namespace, fields.Everything())
+
+	_, informer := cache.NewInformer(source, &PlaybookRun{}, 30*time.Second, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.reconcile(copyPlaybookRun(obj.(*PlaybookRun)))
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.reconcile(copyPlaybookRun(newObj.(*PlaybookRun)))
+		},
+	})
+
+	informer.Run(stopCh)
+}
+
+// copyPlaybookRun deep-copies run so reconcile can mutate .Status without
+// racing the informer's shared cache, which owns run itself.
+func copyPlaybookRun(run *PlaybookRun) *PlaybookRun {
+	return run.DeepCopyObject().(*PlaybookRun)
+}
+
+// reconcile drives a single PlaybookRun towards completion: it generates
+// the Job on first sight, and otherwise syncs .status from the Job it
+// already created.
+func (c *PlaybookRunController) reconcile(run *PlaybookRun) {
+	if run.Status.Phase == PlaybookRunPhaseSucceeded || run.Status.Phase == PlaybookRunPhaseFailed {
+		return
+	}
+
+	var err error
+	if run.Status.JobRef == "" {
+		err = c.startJob(run)
+	} else {
+		err = c.syncJobStatus(run)
+	}
+	if err != nil {
+		fmt.Printf("error reconciling playbookrun %s/%s: %s\n", run.Namespace, run.Name, err.Error())
+	}
+}
+
+// startJob generates a uniquely-named Job for run, owned by run so that a
+// PlaybookRun delete cascades to its Job, and records the Job's name and
+// Pending phase onto run's status.
+func (c *PlaybookRunController) startJob(run *PlaybookRun) error {
+	runner := newKubeJobRunner(c.KubeClient, c.RESTConfig, run.Namespace)
+	if run.Spec.Image != "" {
+		runner.Image = run.Spec.Image
+	}
+
+	owner := []metav1.OwnerReference{ownerReferenceFor(run)}
+
+	jobName := fmt.Sprintf("%s-job", run.Name)
+
+	inventoryCM := run.Spec.InventoryConfigMapRef
+	if inventoryCM == "" {
+		// Per-run name, owned by run, so concurrent PlaybookRuns with
+		// inline Inventory don't clobber each other's ConfigMap and it's
+		// garbage-collected when run is deleted.
+		inventoryCM = fmt.Sprintf("%s-inventory", run.Name)
+		if err := runner.createConfigMap(inventoryCM, run.Spec.Inventory, owner); err != nil {
+			return err
+		}
+	}
+
+	deadline := run.Spec.ActiveDeadlineSeconds
+	if deadline == 0 {
+		deadline = 60 * 60
+	}
+
+	job := buildAnsibleJob(ansibleJobOptions{
+		Name:               jobName,
+		Namespace:          run.Namespace,
+		Image:              runner.Image,
+		Playbook:           run.Spec.Playbook,
+		InventoryConfigMap: inventoryCM,
+		SSHSecret:          run.Spec.SSHSecretRef,
+		ServiceAccountName: openshiftAnsibleServiceAccount,
+		ActiveDeadlineSecs: deadline,
+		ExtraEnv:           run.Spec.Env,
+		ExtraVars:          run.Spec.ExtraVars,
+		OwnerReferences:    owner,
+	})
+
+	_, err := c.KubeClient.Batch().Jobs(run.Namespace).Create(job)
+	if err != nil && !kapierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	run.Status.Phase = PlaybookRunPhasePending
+	run.Status.JobRef = jobName
+	run.Status.Message = "job created"
+	return c.updateStatus(run)
+}
+
+// syncJobStatus reads the Job named by run.Status.JobRef and copies its
+// running or terminal state, if any, back onto run.Status.
+func (c *PlaybookRunController) syncJobStatus(run *PlaybookRun) error {
+	job, err := c.KubeClient.Batch().Jobs(run.Namespace).Get(run.Status.JobRef, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case job.Status.Active > 0 && run.Status.Phase == PlaybookRunPhasePending:
+		run.Status.Phase = PlaybookRunPhaseRunning
+		if job.Status.StartTime != nil {
+			run.Status.StartTime = job.Status.StartTime
+		}
+		run.Status.Message = "job running"
+	case job.Status.Succeeded > 0:
+		run.Status.Phase = PlaybookRunPhaseSucceeded
+		run.Status.CompletionTime = job.Status.CompletionTime
+		run.Status.Message = "playbook completed successfully"
+	case job.Status.Failed > 0:
+		run.Status.Phase = PlaybookRunPhaseFailed
+		now := metav1.Now()
+		run.Status.CompletionTime = &now
+		run.Status.Message = "playbook job failed"
+	default:
+		return nil
+	}
+
+	return c.updateStatus(run)
+}
+
+// updateStatus writes run.Status back to the apiserver, retrying on
+// conflict since the informer's cached copy may lag concurrent updates.
+// Each attempt re-reads the latest PlaybookRun and applies run.Status to it.
+func (c *PlaybookRunController) updateStatus(run *PlaybookRun) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &PlaybookRun{}
+		if err := c.CRDClient.Get().
+			Namespace(run.Namespace).
+			Resource(PlaybookRunResourcePlural).
+			Name(run.Name).
+			Do().
+			Into(latest); err != nil {
+			return err
+		}
+		latest.Status = run.Status
+		return c.CRDClient.Put().
+			Namespace(run.Namespace).
+			Resource(PlaybookRunResourcePlural).
+			Name(run.Name).
+			SubResource("status").
+			Body(latest).
+			Do().
+			Error()
+	})
+}
+
+// ownerReferenceFor builds the OwnerReference used to make a PlaybookRun's
+// generated Job (and inline-inventory ConfigMap) subject to Kubernetes
+// garbage collection when the PlaybookRun is deleted.
+func ownerReferenceFor(run *PlaybookRun) metav1.OwnerReference {
+	blockOwnerDeletion := true
+	controller := true
+	return metav1.OwnerReference{
+		APIVersion:         PlaybookRunSchemeGroupVersion.String(),
+		Kind:               PlaybookRunResourceKind,
+		Name:               run.Name,
+		UID:                run.UID,
+		BlockOwnerDeletion: &blockOwnerDeletion,
+		Controller:         &controller,
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// PlaybookRunResourceKind and PlaybookRunResourcePlural name the CRD as
+	// registered with the apiserver.
+	PlaybookRunResourceKind   = "PlaybookRun"
+	PlaybookRunResourcePlural = "playbookruns"
+
+	// PlaybookRunGroupName and PlaybookRunVersion identify the CRD's group
+	// and version for client and scheme registration.
+	PlaybookRunGroupName = "ansible.o-a-pod.io"
+	PlaybookRunVersion   = "v1alpha1"
+)
+
+// PlaybookRunSchemeGroupVersion is the GroupVersion used by PlaybookRun and
+// PlaybookRunList.
+var PlaybookRunSchemeGroupVersion = schema.GroupVersion{Group: PlaybookRunGroupName, Version: PlaybookRunVersion}
+
+// addPlaybookRunTypes registers PlaybookRun and PlaybookRunList with scheme,
+// required since this repository predates client-gen/deepcopy-gen.
+func addPlaybookRunTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(PlaybookRunSchemeGroupVersion, &PlaybookRun{}, &PlaybookRunList{})
+	metav1.AddToGroupVersion(scheme, PlaybookRunSchemeGroupVersion)
+	return nil
+}
+
+// PlaybookRun is the CRD o-a-pod's controller reconciles: it names a
+// playbook, an inventory source, and the image/SSH key to run it with, and
+// is reconciled into a Job per run.
+type PlaybookRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PlaybookRunSpec   `json:"spec"`
+	Status PlaybookRunStatus `json:"status,omitempty"`
+}
+
+// PlaybookRunSpec is the desired state of a PlaybookRun.
+type PlaybookRunSpec struct {
+	// Playbook is the path to the playbook to run, relative to the ansible
+	// image's playbook directory (e.g. "playbooks/byo/config.yml").
+	Playbook string `json:"playbook"`
+
+	// Inventory is the inventory content itself. Exactly one of Inventory
+	// and InventoryConfigMapRef must be set.
+	Inventory string `json:"inventory,omitempty"`
+
+	// InventoryConfigMapRef names an existing ConfigMap (with a "hosts" key)
+	// to use as the inventory instead of Inventory.
+	InventoryConfigMapRef string `json:"inventoryConfigMapRef,omitempty"`
+
+	// ExtraVars are rendered as additional ansible-playbook --extra-vars.
+	ExtraVars map[string]string `json:"extraVars,omitempty"`
+
+	// Image overrides the runner's default ansible image ("image:tag").
+	Image string `json:"image,omitempty"`
+
+	// SSHSecretRef names the Secret (with an "ssh-privatekey" key) used to
+	// reach the playbook's targets.
+	SSHSecretRef string `json:"sshSecretRef"`
+
+	// ActiveDeadlineSeconds bounds how long the generated Job may run.
+	ActiveDeadlineSeconds int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// Env are additional environment variables merged into the ansible
+	// container.
+	Env []kapi.EnvVar `json:"env,omitempty"`
+}
+
+// PlaybookRunPhase is a high level summary of where a PlaybookRun is in its
+// lifecycle.
+type PlaybookRunPhase string
+
+const (
+	PlaybookRunPhasePending   PlaybookRunPhase = "Pending"
+	PlaybookRunPhaseRunning   PlaybookRunPhase = "Running"
+	PlaybookRunPhaseSucceeded PlaybookRunPhase = "Succeeded"
+	PlaybookRunPhaseFailed    PlaybookRunPhase = "Failed"
+)
+
+// PlaybookRunCondition is a timestamped observation of some aspect of a
+// PlaybookRun's status.
+type PlaybookRunCondition struct {
+	Type               string               `json:"type"`
+	Status             kapi.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time          `json:"lastTransitionTime,omitempty"`
+	Reason             string               `json:"reason,omitempty"`
+	Message            string               `json:"message,omitempty"`
+}
+
+// PlaybookRunStatus is written back by the controller as it drives a
+// PlaybookRun's Job to completion.
+type PlaybookRunStatus struct {
+	Phase          PlaybookRunPhase       `json:"phase,omitempty"`
+	Conditions     []PlaybookRunCondition `json:"conditions,omitempty"`
+	JobRef         string                 `json:"jobRef,omitempty"`
+	StartTime      *metav1.Time           `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time           `json:"completionTime,omitempty"`
+	Message        string                 `json:"message,omitempty"`
+}
+
+// PlaybookRunList is a list of PlaybookRuns.
+type PlaybookRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PlaybookRun `json:"items"`
+}
+
+// DeepCopyObject satisfies runtime.Object. Hand-written because this
+// repository predates deepcopy-gen.
+func (p *PlaybookRun) DeepCopyObject() runtime.Object {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	out.ObjectMeta = *p.ObjectMeta.DeepCopy()
+	if p.Spec.ExtraVars != nil {
+		out.Spec.ExtraVars = make(map[string]string, len(p.Spec.ExtraVars))
+		for k, v := range p.Spec.ExtraVars {
+			out.Spec.ExtraVars[k] = v
+		}
+	}
+	if p.Spec.Env != nil {
+		out.Spec.Env = make([]kapi.EnvVar, len(p.Spec.Env))
+		copy(out.Spec.Env, p.Spec.Env)
+	}
+	if p.Status.Conditions != nil {
+		out.Status.Conditions = make([]PlaybookRunCondition, len(p.Status.Conditions))
+		copy(out.Status.Conditions, p.Status.Conditions)
+	}
+	if p.Status.StartTime != nil {
+		t := *p.Status.StartTime
+		out.Status.StartTime = &t
+	}
+	if p.Status.CompletionTime != nil {
+		t := *p.Status.CompletionTime
+		out.Status.CompletionTime = &t
+	}
+	return &out
+}
+
+// DeepCopyObject satisfies runtime.Object.
+func (p *PlaybookRunList) DeepCopyObject() runtime.Object {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	out.Items = make([]PlaybookRun, len(p.Items))
+	for i := range p.Items {
+		out.Items[i] = *(p.Items[i].DeepCopyObject().(*PlaybookRun))
+	}
+	return &out
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	kapi "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	securityv1 "github.com/openshift/api/security/v1"
+	securityclient "github.com/openshift/client-go/security/clientset/versioned"
+)
+
+// ansiblePrivilegedSCCName is the SecurityContextConstraints created to
+// cover the ansible Job's runAsUser: 0 and hostNetwork: true requirements.
+const ansiblePrivilegedSCCName = "o-a-pod-ansible"
+
+// EnsurePrerequisites creates (or updates) the Namespace, ServiceAccount,
+// and RBAC that RunPlaybook's Job assumes already exist. On OpenShift it
+// also binds a SecurityContextConstraints permitting the runAsUser: 0 and
+// hostNetwork: true the ansible container needs.
+func (r *KubeJobRunner) EnsurePrerequisites(ctx context.Context) error {
+	if err := r.ensureNamespace(); err != nil {
+		return err
+	}
+	if err := r.ensureServiceAccount(); err != nil {
+		return err
+	}
+	if err := r.ensureRBAC(); err != nil {
+		return err
+	}
+
+	isOpenShift, err := r.isOpenShift()
+	if err != nil {
+		return err
+	}
+	if !isOpenShift {
+		return nil
+	}
+	return r.ensureSCC()
+}
+
+func (r *KubeJobRunner) ensureNamespace() error {
+	ns := &kapi.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: r.Namespace},
+	}
+	_, err := r.KubeClient.CoreV1().Namespaces().Create(ns)
+	if err != nil && !kapierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating namespace %s: %s", r.Namespace, err.Error())
+	}
+	return nil
+}
+
+func (r *KubeJobRunner) ensureServiceAccount() error {
+	sa := &kapi.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      openshiftAnsibleServiceAccount,
+			Namespace: r.Namespace,
+		},
+	}
+	_, err := r.KubeClient.CoreV1().ServiceAccounts(r.Namespace).Create(sa)
+	if err != nil && !kapierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating service account %s: %s", openshiftAnsibleServiceAccount, err.Error())
+	}
+	return nil
+}
+
+// ensureRBAC grants the openshiftAnsibleServiceAccount the permissions the
+// playbook Job needs to manage its own inventory ConfigMap and report its
+// Pod's status and logs.
+func (r *KubeJobRunner) ensureRBAC() error {
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      openshiftAnsibleServiceAccount,
+			Namespace: r.Namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"batch"},
+				Resources: []string{"jobs"},
+				Verbs:     []string{"get", "list", "watch", "create", "update"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods", "pods/log", "configmaps", "secrets"},
+				Verbs:     []string{"get", "list", "watch", "create", "update"},
+			},
+		},
+	}
+	_, err := r.KubeClient.RbacV1().Roles(r.Namespace).Create(role)
+	if kapierrors.IsAlreadyExists(err) {
+		_, err = r.KubeClient.RbacV1().Roles(r.Namespace).Update(role)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating role %s: %s", openshiftAnsibleServiceAccount, err.Error())
+	}
+
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      openshiftAnsibleServiceAccount,
+			Namespace: r.Namespace,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      openshiftAnsibleServiceAccount,
+				Namespace: r.Namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     openshiftAnsibleServiceAccount,
+		},
+	}
+	_, err = r.KubeClient.RbacV1().RoleBindings(r.Namespace).Create(binding)
+	if kapierrors.IsAlreadyExists(err) {
+		_, err = r.KubeClient.RbacV1().RoleBindings(r.Namespace).Update(binding)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating role binding %s: %s", openshiftAnsibleServiceAccount, err.Error())
+	}
+	return nil
+}
+
+// isOpenShift probes API discovery for the security.openshift.io group,
+// which is only registered on OpenShift clusters.
+func (r *KubeJobRunner) isOpenShift() (bool, error) {
+	groups, err := r.KubeClient.Discovery().ServerGroups()
+	if err != nil {
+		return false, fmt.Errorf("error probing server groups: %s", err.Error())
+	}
+	for _, g := range groups.Groups {
+		if g.Name == "security.openshift.io" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ensureSCC patches the runner's SecurityContextConstraints' users list to
+// include this runner's ServiceAccount, creating the SCC first if it
+// doesn't yet exist.
+func (r *KubeJobRunner) ensureSCC() error {
+	securityClient, err := securityclient.NewForConfig(r.RESTConfig)
+	if err != nil {
+		return fmt.Errorf("error building security client: %s", err.Error())
+	}
+	sccClient := securityClient.SecurityV1().SecurityContextConstraints()
+
+	user := fmt.Sprintf("system:serviceaccount:%s:%s", r.Namespace, openshiftAnsibleServiceAccount)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scc, err := sccClient.Get(ansiblePrivilegedSCCName, metav1.GetOptions{})
+		if kapierrors.IsNotFound(err) {
+			_, createErr := sccClient.Create(&securityv1.SecurityContextConstraints{
+				ObjectMeta: metav1.ObjectMeta{Name: ansiblePrivilegedSCCName},
+				RunAsUser: securityv1.RunAsUserStrategyOptions{
+					Type: securityv1.RunAsUserStrategyRunAsAny,
+				},
+				SELinuxContext: securityv1.SELinuxContextStrategyOptions{
+					Type: securityv1.SELinuxStrategyRunAsAny,
+				},
+				AllowHostNetwork: true,
+				Users:            []string{user},
+			})
+			return createErr
+		}
+		if err != nil {
+			return fmt.Errorf("error getting scc %s: %s", ansiblePrivilegedSCCName, err.Error())
+		}
+
+		for _, existing := range scc.Users {
+			if existing == user {
+				return nil
+			}
+		}
+		scc.Users = append(scc.Users, user)
+		_, err = sccClient.Update(scc)
+		return err
+	})
+}
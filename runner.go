@@ -0,0 +1,41 @@
+package main
+
+import "io"
+
+// RunOptions carries the inputs needed to execute a playbook that are
+// independent of which Runner executes it.
+type RunOptions struct {
+	// ExtraVars are passed through to ansible-playbook as --extra-vars.
+	ExtraVars map[string]string
+
+	// SSHPrivateKey is the private key material used to reach the
+	// playbook's targets. Exactly one of SSHPrivateKey and
+	// SSHPrivateKeyPath should be set.
+	SSHPrivateKey []byte
+
+	// SSHPrivateKeyPath is a path to the private key material, for callers
+	// that have it on disk rather than in memory.
+	SSHPrivateKeyPath string
+
+	// KeepArtifacts retains the per-run ConfigMap/Secret KubeJobRunner
+	// creates (inventory and SSH key) after the playbook completes, for
+	// post-mortem debugging. They're cleaned up by default.
+	KeepArtifacts bool
+
+	// Log receives the playbook's output as it runs.
+	Log io.Writer
+}
+
+// Result is the outcome of a single playbook run.
+type Result struct {
+	Succeeded bool
+	Message   string
+}
+
+// Runner executes a playbook against an inventory. Implementations differ
+// in where the playbook actually runs: inside a Kubernetes Job
+// (KubeJobRunner), over SSH against a static host list (SSHHostListRunner),
+// or as a local subprocess (LocalRunner).
+type Runner interface {
+	RunPlaybook(inventory string, playbook string, opts RunOptions) (Result, error)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SSHHost is a single target for SSHHostListRunner.
+type SSHHost struct {
+	Address string
+	User    string
+	Port    int
+}
+
+// SSHHostListRunner runs ansible-playbook locally against an inventory
+// built from a static list of hosts reached over SSH, rather than the
+// ConfigMap-backed inventory a KubeJobRunner Job mounts. This is the
+// host-list provider pattern, useful for bootstrapping a cluster before one
+// exists to run a Job against.
+type SSHHostListRunner struct {
+	Hosts             []SSHHost
+	SSHPrivateKeyPath string
+	Binary            string
+}
+
+func newSSHHostListRunner(hosts []SSHHost, sshPrivateKeyPath string) *SSHHostListRunner {
+	return &SSHHostListRunner{
+		Hosts:             hosts,
+		SSHPrivateKeyPath: sshPrivateKeyPath,
+		Binary:            "ansible-playbook",
+	}
+}
+
+// RunPlaybook satisfies the Runner interface. If inventory is empty, it's
+// rendered from r.Hosts.
+func (r *SSHHostListRunner) RunPlaybook(inventory string, playbook string, opts RunOptions) (Result, error) {
+	if inventory == "" {
+		inventory = r.renderInventory()
+	}
+
+	inventoryFile, err := writeTempInventory(inventory)
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.Remove(inventoryFile)
+
+	args := []string{"-i", inventoryFile}
+	if r.SSHPrivateKeyPath != "" {
+		args = append(args, "--private-key", r.SSHPrivateKeyPath)
+	}
+	args = append(args, extraVarsArgs(opts.ExtraVars)...)
+	args = append(args, playbook)
+
+	return runAnsiblePlaybookLocally(r.Binary, args, opts.Log)
+}
+
+// parseSSHHosts parses a comma-separated list of "[user@]host[:port]"
+// targets, as accepted by the --ssh-hosts flag.
+func parseSSHHosts(raw string) ([]SSHHost, error) {
+	var hosts []SSHHost
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host := SSHHost{Address: entry}
+		if at := strings.Index(entry, "@"); at != -1 {
+			host.User = entry[:at]
+			host.Address = entry[at+1:]
+		}
+		if colon := strings.Index(host.Address, ":"); colon != -1 {
+			port, err := strconv.Atoi(host.Address[colon+1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid port in ssh host %q: %s", entry, err.Error())
+			}
+			host.Port = port
+			host.Address = host.Address[:colon]
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// renderInventory builds a minimal [hosts] inventory section from r.Hosts.
+func (r *SSHHostListRunner) renderInventory() string {
+	var b strings.Builder
+	b.WriteString("[hosts]\n")
+	for _, h := range r.Hosts {
+		line := h.Address
+		if h.User != "" {
+			line += fmt.Sprintf(" ansible_user=%s", h.User)
+		}
+		if h.Port != 0 {
+			line += fmt.Sprintf(" ansible_port=%d", h.Port)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
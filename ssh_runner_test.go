@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSSHHosts(t *testing.T) {
+	cases := []struct {
+		name    string
+		raw     string
+		want    []SSHHost
+		wantErr bool
+	}{
+		{
+			name: "single bare host",
+			raw:  "host1.example.com",
+			want: []SSHHost{{Address: "host1.example.com"}},
+		},
+		{
+			name: "user and port",
+			raw:  "core@host1.example.com:2222",
+			want: []SSHHost{{Address: "host1.example.com", User: "core", Port: 2222}},
+		},
+		{
+			name: "multiple hosts, blanks trimmed and skipped",
+			raw:  "core@host1.example.com, host2.example.com ,",
+			want: []SSHHost{
+				{Address: "host1.example.com", User: "core"},
+				{Address: "host2.example.com"},
+			},
+		},
+		{
+			name:    "invalid port",
+			raw:     "host1.example.com:notaport",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseSSHHosts(tc.raw)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseSSHHosts(%q): expected an error, got none", tc.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSSHHosts(%q): unexpected error: %s", tc.raw, err.Error())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseSSHHosts(%q) = %+v, want %+v", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
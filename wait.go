@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	kbatch "k8s.io/api/batch/v1"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// defaultLogStreamBackoff governs retries against the pod log/exec
+// endpoints, which tend to drop connections while the ansible container is
+// still starting up. It's the default for KubeJobRunner.LogStreamBackoff.
+var defaultLogStreamBackoff = wait.Backoff{
+	Duration: 2 * time.Second,
+	Factor:   2.0,
+	Steps:    5,
+}
+
+// defaultWatchBackoff governs reconnecting the Job watch after its result
+// channel closes, which happens routinely once the apiserver's watch
+// timeout elapses and is independent of whether the Job is still running.
+// It's the default for KubeJobRunner.WatchBackoff.
+var defaultWatchBackoff = wait.Backoff{
+	Duration: 1 * time.Second,
+	Factor:   2.0,
+	Steps:    10,
+}
+
+// PlaybookResult is the terminal outcome of a playbook run, as observed by
+// watching the Job/Pod it was submitted in.
+type PlaybookResult struct {
+	Succeeded      bool
+	ExitCode       int32
+	StartTime      time.Time
+	CompletionTime time.Time
+	ContainerState kapi.ContainerState
+	Message        string
+}
+
+// WaitForCompletion watches the Job created by the most recent RunPlaybook
+// call until it reaches a terminal state, streaming the ansible container's
+// logs to out as they become available. It returns once the Job succeeds,
+// fails, or ctx is cancelled.
+func (r *KubeJobRunner) WaitForCompletion(ctx context.Context, out io.Writer) (*PlaybookResult, error) {
+	if r.JobName == "" {
+		return nil, fmt.Errorf("no job has been run yet, call RunPlaybook first")
+	}
+
+	watcher, err := r.watchJob()
+	if err != nil {
+		return nil, fmt.Errorf("error watching job %s: %s", r.JobName, err.Error())
+	}
+	defer func() {
+		watcher.Stop()
+	}()
+
+	var streamed bool
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				// The apiserver closes watch connections on its own timeout
+				// independent of whether the Job is still running; reconnect
+				// rather than treating this as a hard failure.
+				watcher.Stop()
+				watcher, err = r.reconnectJobWatch()
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			job, ok := event.Object.(*kbatch.Job)
+			if !ok {
+				continue
+			}
+
+			if !streamed && r.podIsRunning(job) {
+				// Best-effort: log streaming failures shouldn't fail the run,
+				// the Job's terminal state is the source of truth.
+				if streamErr := r.streamPodLogs(ctx, job, out); streamErr != nil {
+					fmt.Fprintf(out, "error streaming logs: %s\n", streamErr.Error())
+				}
+				streamed = true
+			}
+
+			if result := r.jobResult(job); result != nil {
+				if !streamed {
+					r.streamPodLogs(ctx, job, out)
+				}
+				return result, nil
+			}
+		}
+	}
+}
+
+// watchJob opens a watch scoped to r.JobName.
+func (r *KubeJobRunner) watchJob() (watch.Interface, error) {
+	return r.KubeClient.Batch().Jobs(r.Namespace).Watch(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", r.JobName).String(),
+	})
+}
+
+// reconnectJobWatch re-establishes the Job watch with r.WatchBackoff,
+// giving up once the backoff is exhausted.
+func (r *KubeJobRunner) reconnectJobWatch() (watch.Interface, error) {
+	var watcher watch.Interface
+	err := wait.ExponentialBackoff(r.WatchBackoff, func() (bool, error) {
+		w, watchErr := r.watchJob()
+		if watchErr != nil {
+			return false, nil
+		}
+		watcher = w
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("giving up reconnecting job watch for %s: %s", r.JobName, err.Error())
+	}
+	return watcher, nil
+}
+
+// podIsRunning reports whether the Job has an associated Pod that has
+// started, and is therefore ready to have its logs streamed.
+func (r *KubeJobRunner) podIsRunning(job *kbatch.Job) bool {
+	return job.Status.Active > 0
+}
+
+// jobResult inspects the Job's status and returns a PlaybookResult once the
+// Job has reached a terminal state, or nil while it's still running.
+func (r *KubeJobRunner) jobResult(job *kbatch.Job) *PlaybookResult {
+	switch {
+	case job.Status.Succeeded > 0:
+		result := &PlaybookResult{
+			Succeeded: true,
+			Message:   "playbook completed successfully",
+		}
+		if job.Status.StartTime != nil {
+			result.StartTime = job.Status.StartTime.Time
+		}
+		if job.Status.CompletionTime != nil {
+			result.CompletionTime = job.Status.CompletionTime.Time
+		}
+		return result
+	case job.Status.Failed > 0:
+		result := &PlaybookResult{
+			Succeeded: false,
+			Message:   "playbook job failed",
+		}
+		if job.Status.StartTime != nil {
+			result.StartTime = job.Status.StartTime.Time
+		}
+		result.CompletionTime = time.Now()
+		if state, exitCode, ok := r.terminalContainerState(job); ok {
+			result.ContainerState = state
+			result.ExitCode = exitCode
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// terminalContainerState fetches the Pod backing job and returns the
+// terminated state of its ansible container, if available.
+func (r *KubeJobRunner) terminalContainerState(job *kbatch.Job) (kapi.ContainerState, int32, bool) {
+	pod, err := r.findPodForJob(job)
+	if err != nil || pod == nil {
+		return kapi.ContainerState{}, 0, false
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == job.Name && cs.State.Terminated != nil {
+			return cs.State, cs.State.Terminated.ExitCode, true
+		}
+	}
+	return kapi.ContainerState{}, 0, false
+}
+
+// findPodForJob locates the single Pod created for job via its job-name
+// label, the same label the Job controller applies to Pods it owns.
+func (r *KubeJobRunner) findPodForJob(job *kbatch.Job) (*kapi.Pod, error) {
+	pods, err := r.KubeClient.CoreV1().Pods(r.Namespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", job.Name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, nil
+	}
+	return &pods.Items[0], nil
+}
+
+// streamPodLogs tails the ansible container's logs to out, retrying with
+// backoff since the log endpoint commonly isn't ready the moment the Pod
+// transitions to Running.
+func (r *KubeJobRunner) streamPodLogs(ctx context.Context, job *kbatch.Job, out io.Writer) error {
+	pod, err := r.findPodForJob(job)
+	if err != nil {
+		return err
+	}
+	if pod == nil {
+		return fmt.Errorf("no pod found for job %s", job.Name)
+	}
+
+	var stream io.ReadCloser
+	err = wait.ExponentialBackoff(r.LogStreamBackoff, func() (bool, error) {
+		s, streamErr := r.KubeClient.CoreV1().Pods(r.Namespace).GetLogs(pod.Name, &kapi.PodLogOptions{
+			Container: job.Name,
+			Follow:    true,
+		}).Stream()
+		if streamErr != nil {
+			fmt.Fprintf(out, "retrying log stream for pod %s: %s\n", pod.Name, streamErr.Error())
+			return false, nil
+		}
+		stream = s
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("giving up streaming logs for pod %s: %s", pod.Name, err.Error())
+	}
+	defer stream.Close()
+
+	_, err = io.Copy(out, stream)
+	return err
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	kbatch "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestJobResult(t *testing.T) {
+	now := metav1.Now()
+	later := metav1.NewTime(now.Add(time.Minute))
+
+	cases := []struct {
+		name          string
+		job           *kbatch.Job
+		wantNil       bool
+		wantSucceeded bool
+	}{
+		{
+			name:    "still running",
+			job:     &kbatch.Job{Status: kbatch.JobStatus{Active: 1}},
+			wantNil: true,
+		},
+		{
+			name: "succeeded",
+			job: &kbatch.Job{Status: kbatch.JobStatus{
+				Succeeded:      1,
+				StartTime:      &now,
+				CompletionTime: &later,
+			}},
+			wantSucceeded: true,
+		},
+		{
+			name: "succeeded with no timestamps recorded yet",
+			job: &kbatch.Job{Status: kbatch.JobStatus{
+				Succeeded: 1,
+			}},
+			wantSucceeded: true,
+		},
+		{
+			name: "failed",
+			job: &kbatch.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-job"},
+				Status:     kbatch.JobStatus{Failed: 1, StartTime: &now},
+			},
+			wantSucceeded: false,
+		},
+		{
+			name: "failed with no start time recorded yet",
+			job: &kbatch.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-job"},
+				Status:     kbatch.JobStatus{Failed: 1},
+			},
+			wantSucceeded: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &KubeJobRunner{KubeClient: fake.NewSimpleClientset(), Namespace: "ansible-test"}
+			result := r.jobResult(tc.job)
+
+			if tc.wantNil {
+				if result != nil {
+					t.Fatalf("jobResult(%s) = %+v, want nil", tc.name, result)
+				}
+				return
+			}
+			if result == nil {
+				t.Fatalf("jobResult(%s) = nil, want a result", tc.name)
+			}
+			if result.Succeeded != tc.wantSucceeded {
+				t.Errorf("Succeeded = %v, want %v", result.Succeeded, tc.wantSucceeded)
+			}
+		})
+	}
+}